@@ -0,0 +1,95 @@
+/*
+Copyright 2014 Zachary Klippenstein
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package regen
+
+import (
+	"errors"
+	"math/rand"
+)
+
+// ErrOutputTruncated is returned alongside a generated string by Generate,
+// GenerateSeeded, GenerateTraced and Replay when GeneratorArgs.MaxOutputLength
+// cut the output short. The returned string is whatever was produced before
+// the budget ran out, not a string matching the original pattern.
+var ErrOutputTruncated = errors.New("regen: output truncated at MaxOutputLength")
+
+// minUnboundedRepeatCount returns the effective minimum repeat count to use
+// when a repetition's minimum comes in as "unbounded".
+func (args *GeneratorArgs) minUnboundedRepeatCount() int {
+	if args.MinUnboundedRepeatCount > 0 {
+		return args.MinUnboundedRepeatCount
+	}
+	return 0
+}
+
+// maxUnboundedRepeatCount returns the effective maximum repeat count to use
+// when a repetition's maximum comes in as "unbounded" (e.g. the `*` in
+// `a*`), replacing the package's hardcoded maxUpperBound.
+func (args *GeneratorArgs) maxUnboundedRepeatCount() int {
+	if args.MaxUnboundedRepeatCount > 0 {
+		return args.MaxUnboundedRepeatCount
+	}
+	return maxUpperBound
+}
+
+// newRuntimeArgs builds the runtimeArgs for a single top-level Generate
+// call, seeding its output-length budget from args.MaxOutputLength.
+func (args *GeneratorArgs) newRuntimeArgs(rng *rand.Rand) *runtimeArgs {
+	remaining := -1
+	if args.MaxOutputLength > 0 {
+		remaining = args.MaxOutputLength
+	}
+	return &runtimeArgs{Rng: rng, remaining: remaining}
+}
+
+// consume deducts n from args.remaining, if a budget is in effect, never
+// going below zero.
+func (args *runtimeArgs) consume(n int) {
+	if args.remaining < 0 {
+		return
+	}
+	args.remaining -= n
+	if args.remaining < 0 {
+		args.remaining = 0
+	}
+}
+
+// withinBudget reports whether there's still room to generate more output.
+// Always true when no MaxOutputLength budget is in effect.
+func (args *runtimeArgs) withinBudget() bool {
+	return args.remaining != 0
+}
+
+// markTruncated records that the budget ran out before generation finished,
+// so the public Generate can report ErrOutputTruncated.
+func (args *runtimeArgs) markTruncated() {
+	args.truncated = true
+}
+
+// enforceBudget is the backstop the public Generate methods apply to their
+// finished result. concatExecutor already truncates piece by piece as it
+// goes, but only concat and repeat route through it -- a pattern whose
+// simplified root is a bare literal, char class or `.` never calls
+// args.consume at all, so it needs this final check to actually honor
+// MaxOutputLength. Returns the (possibly truncated) result and whether
+// ErrOutputTruncated should be reported.
+func enforceBudget(genArgs *GeneratorArgs, runArgs *runtimeArgs, result string) (string, bool) {
+	if genArgs.MaxOutputLength > 0 && len(result) > genArgs.MaxOutputLength {
+		return result[:genArgs.MaxOutputLength], true
+	}
+	return result, runArgs.truncated
+}
@@ -0,0 +1,72 @@
+/*
+Copyright 2014 Zachary Klippenstein
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package regen
+
+import "testing"
+
+// A single atom (literal, nested capture, ...) wider than the remaining
+// budget must be truncated itself, not emitted in full.
+func TestMaxOutputLengthCapsSingleOversizedPiece(t *testing.T) {
+	cases := []string{"hello(world)?", "(abc){1,20}"}
+
+	for _, pattern := range cases {
+		gen, err := NewGenerator(pattern, &GeneratorArgs{MaxOutputLength: 2})
+		if err != nil {
+			t.Fatalf("/%s/: NewGenerator: %v", pattern, err)
+		}
+
+		value, err := gen.Generate()
+		if err != ErrOutputTruncated {
+			t.Errorf("/%s/: got err %v, want ErrOutputTruncated", pattern, err)
+		}
+		if len(value) > 2 {
+			t.Errorf("/%s/: got %q (%d bytes), want at most 2 bytes", pattern, value, len(value))
+		}
+	}
+}
+
+// A pattern whose simplified AST root is a bare literal never routes through
+// concatExecutor, so MaxOutputLength must be enforced as a backstop over the
+// whole result rather than relying solely on per-piece truncation.
+func TestMaxOutputLengthCapsBareLiteralRoot(t *testing.T) {
+	pattern := "aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"
+	gen, err := NewGenerator(pattern, &GeneratorArgs{MaxOutputLength: 3})
+	if err != nil {
+		t.Fatalf("NewGenerator: %v", err)
+	}
+
+	value, err := gen.Generate()
+	if err != ErrOutputTruncated {
+		t.Fatalf("got err %v, want ErrOutputTruncated", err)
+	}
+	if len(value) != 3 {
+		t.Fatalf("got %q (%d bytes), want exactly 3 bytes", value, len(value))
+	}
+}
+
+// MinUnboundedRepeatCount greater than MaxUnboundedRepeatCount is invalid
+// configuration and must be rejected up front instead of panicking out of
+// pickRepeatCount on the first unbounded repetition it applies to.
+func TestInvertedUnboundedRepeatBoundsRejected(t *testing.T) {
+	_, err := NewGenerator("a*", &GeneratorArgs{
+		MinUnboundedRepeatCount: 50,
+		MaxUnboundedRepeatCount: 10,
+	})
+	if err == nil {
+		t.Fatal("got nil error, want an error for MinUnboundedRepeatCount > MaxUnboundedRepeatCount")
+	}
+}
@@ -0,0 +1,51 @@
+/*
+Copyright 2014 Zachary Klippenstein
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package regen
+
+import "testing"
+
+// A character class with no bytes in [0,255] must be rejected with an error
+// instead of panicking when Generate is later called.
+func TestNewByteGeneratorRejectsOutOfRangeCharClass(t *testing.T) {
+	_, err := NewByteGenerator(`[\x{1F600}\x{1F601}]`, nil)
+	if err == nil {
+		t.Fatal("NewByteGenerator: got nil error, want an error rejecting the out-of-range class")
+	}
+}
+
+// NewByteGenerator must leave the caller's GeneratorArgs untouched, so
+// reusing the same struct with NewGenerator afterward doesn't silently
+// produce byte-mode output too.
+func TestNewByteGeneratorDoesNotMutateCallerArgs(t *testing.T) {
+	args := &GeneratorArgs{}
+
+	if _, err := NewByteGenerator("a", args); err != nil {
+		t.Fatalf("NewByteGenerator: %v", err)
+	}
+
+	if args.ByteMode {
+		t.Fatal("NewByteGenerator mutated the caller's GeneratorArgs.ByteMode")
+	}
+
+	gen, err := NewGenerator("a", args)
+	if err != nil {
+		t.Fatalf("NewGenerator: %v", err)
+	}
+	if value, _ := gen.Generate(); value != "a" {
+		t.Fatalf("got %q, want \"a\"", value)
+	}
+}
@@ -0,0 +1,71 @@
+/*
+Copyright 2014 Zachary Klippenstein
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package regen
+
+import (
+	"regexp/syntax"
+	"testing"
+)
+
+// passThroughHandler is a CaptureGroupHandler that just reproduces the
+// group's default generation, as if no handler were set at all.
+func passThroughHandler(index int, name string, group *syntax.Regexp, subGenerator Generator, args *GeneratorArgs) string {
+	value, _ := subGenerator.Generate()
+	return value
+}
+
+// A capture group's sub-generation must count against the parent call's
+// MaxOutputLength budget rather than against a fresh budget of its own.
+func TestCaptureGroupHandlerHonorsOutputBudget(t *testing.T) {
+	gen, err := NewGenerator(`a(?P<x>b*)c.*`, &GeneratorArgs{
+		MaxOutputLength:     5,
+		CaptureGroupHandler: passThroughHandler,
+	})
+	if err != nil {
+		t.Fatalf("NewGenerator: %v", err)
+	}
+
+	for i := 0; i < 20; i++ {
+		value, err := gen.Generate()
+		if len(value) > 5 {
+			t.Fatalf("got %q (%d bytes), want at most 5 bytes (err=%v)", value, len(value), err)
+		}
+	}
+}
+
+// GenerateTraced + Replay must reproduce the same string for a pattern with
+// a pass-through CaptureGroupHandler, since the capture's random draws need
+// to be recorded into (and replayed from) the parent call's Trace.
+func TestCaptureGroupHandlerTraceReplay(t *testing.T) {
+	gen, err := NewGenerator(`a(?P<x>[bcd]{5})e[fgh]{5}`, &GeneratorArgs{
+		CaptureGroupHandler: passThroughHandler,
+	})
+	if err != nil {
+		t.Fatalf("NewGenerator: %v", err)
+	}
+
+	for i := 0; i < 20; i++ {
+		value, trace, _ := GenerateTraced(gen)
+		replayed, err := Replay(gen, trace)
+		if err != nil {
+			t.Fatalf("Replay: %v", err)
+		}
+		if replayed != value {
+			t.Fatalf("Replay produced %q, want %q (original)", replayed, value)
+		}
+	}
+}
@@ -0,0 +1,114 @@
+/*
+Copyright 2014 Zachary Klippenstein
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package regen
+
+// tRuneRange is an inclusive range of runes.
+type tRuneRange struct {
+	Lo, Hi rune
+}
+
+// tCharClass is a set of rune ranges, as produced by a character class in a
+// regular expression (e.g. `[a-z0-9]`).
+type tCharClass struct {
+	Ranges    []tRuneRange
+	TotalSize int32
+}
+
+// newCharClass builds a tCharClass containing the single inclusive range
+// [lo, hi].
+func newCharClass(lo, hi rune) *tCharClass {
+	return &tCharClass{
+		Ranges:    []tRuneRange{{lo, hi}},
+		TotalSize: int32(hi-lo) + 1,
+	}
+}
+
+// parseCharClass builds a tCharClass from runePairs, the [lo, hi, lo, hi, ...]
+// encoding regexp/syntax uses for syntax.Regexp.Rune on OpCharClass nodes.
+func parseCharClass(runePairs []rune) *tCharClass {
+	charClass := &tCharClass{}
+
+	for i := 0; i < len(runePairs); i += 2 {
+		lo, hi := runePairs[i], runePairs[i+1]
+		charClass.Ranges = append(charClass.Ranges, tRuneRange{lo, hi})
+		charClass.TotalSize += int32(hi-lo) + 1
+	}
+
+	return charClass
+}
+
+// GetRuneAt returns the i'th rune in the class, treating the class's ranges
+// as if they were concatenated end to end. i must be in [0, TotalSize).
+func (charClass *tCharClass) GetRuneAt(i int32) rune {
+	for _, r := range charClass.Ranges {
+		size := int32(r.Hi-r.Lo) + 1
+		if i < size {
+			return r.Lo + rune(i)
+		}
+		i -= size
+	}
+	panic("rune index out of range of char class")
+}
+
+// tByteRange is an inclusive range of byte values.
+type tByteRange struct {
+	Lo, Hi byte
+}
+
+// tByteClass is the byte-mode analog of tCharClass: a set of byte ranges
+// produced by clamping a character class's rune ranges into [0,255].
+type tByteClass struct {
+	Ranges    []tByteRange
+	TotalSize int32
+}
+
+// newByteClassFromRunePairs builds a tByteClass from the same [lo, hi, ...]
+// rune-pair encoding parseCharClass consumes, clamping each range to [0,255]
+// and dropping ranges that fall entirely outside it.
+func newByteClassFromRunePairs(runePairs []rune) *tByteClass {
+	byteClass := &tByteClass{}
+
+	for i := 0; i < len(runePairs); i += 2 {
+		lo, hi := runePairs[i], runePairs[i+1]
+		if hi < 0 || lo > 255 {
+			continue
+		}
+		if lo < 0 {
+			lo = 0
+		}
+		if hi > 255 {
+			hi = 255
+		}
+		byteClass.Ranges = append(byteClass.Ranges, tByteRange{byte(lo), byte(hi)})
+		byteClass.TotalSize += int32(hi-lo) + 1
+	}
+
+	return byteClass
+}
+
+// GetByteAt returns the i'th byte in the class, treating the class's ranges
+// as if they were concatenated end to end. i must be in [0, TotalSize).
+func (byteClass *tByteClass) GetByteAt(i int32) byte {
+	for _, r := range byteClass.Ranges {
+		size := int32(r.Hi-r.Lo) + 1
+		if i < size {
+			return r.Lo + byte(i)
+		}
+		i -= size
+	}
+	panic("byte index out of range of byte class")
+}
@@ -0,0 +1,63 @@
+/*
+Copyright 2014 Zachary Klippenstein
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package regen
+
+// Executor determines how the outputs of a sequence of sub-generators (the
+// sub-expressions of a concat, or the repeated expansions of a repeat) are
+// combined into a single string. The default, used whenever GeneratorArgs
+// doesn't specify one, simply runs each generator in order and concatenates
+// the results, stopping early -- and truncating a piece that on its own
+// overshoots the remaining bytes -- if GeneratorArgs.MaxOutputLength is in
+// effect. A custom Executor is responsible for its own budget enforcement if
+// it wants to honor MaxOutputLength.
+type Executor interface {
+	Execute(args *runtimeArgs, generators []*internalGenerator) string
+}
+
+// concatExecutor is the default Executor.
+type concatExecutor struct{}
+
+func (concatExecutor) Execute(args *runtimeArgs, generators []*internalGenerator) string {
+	var result string
+
+	for _, generator := range generators {
+		if !args.withinBudget() {
+			args.markTruncated()
+			break
+		}
+		piece := generator.Generate(args)
+		if args.remaining >= 0 && len(piece) > args.remaining {
+			piece = piece[:args.remaining]
+			args.markTruncated()
+		}
+		result += piece
+		args.consume(len(piece))
+	}
+
+	return result
+}
+
+// executeGeneratorRepeatedly runs generator n times using executor, as if it
+// were a concat of n copies of itself.
+func executeGeneratorRepeatedly(executor Executor, args *runtimeArgs, generator *internalGenerator, n int) string {
+	generators := make([]*internalGenerator, n)
+	for i := range generators {
+		generators[i] = generator
+	}
+
+	return executor.Execute(args, generators)
+}
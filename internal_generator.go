@@ -54,6 +54,24 @@ func init() {
 
 type runtimeArgs struct {
 	Rng *rand.Rand
+
+	// Trace, if non-nil, accumulates the random choices made during this
+	// Generate call (see GenerateTraced).
+	Trace *Trace
+
+	// Replay, if non-nil, supplies choices recorded by a previous Trace in
+	// place of fresh randomness (see Replay).
+	Replay      *Trace
+	replayIndex int
+
+	// remaining is the number of bytes this call may still produce under
+	// its MaxOutputLength budget; -1 means no budget is in effect. See
+	// budget.go.
+	remaining int
+
+	// truncated records whether the budget ran out before generation
+	// finished, so the public Generate can report ErrOutputTruncated.
+	truncated bool
 }
 
 type internalGenerator struct {
@@ -115,17 +133,31 @@ func opLiteral(regexp *syntax.Regexp, args *GeneratorArgs) (*internalGenerator,
 	}}, nil
 }
 
-func opAnyChar(regexp *syntax.Regexp, args *GeneratorArgs) (*internalGenerator, error) {
+func opAnyChar(regexp *syntax.Regexp, genArgs *GeneratorArgs) (*internalGenerator, error) {
 	enforceOp(regexp, syntax.OpAnyChar)
+	if genArgs.ByteMode {
+		return &internalGenerator{regexp.String(), func(args *runtimeArgs) string {
+			return bytesToString(byte(args.nextChoice(CharClassChoice, 256)))
+		}}, nil
+	}
 	return &internalGenerator{regexp.String(), func(args *runtimeArgs) string {
-		return runesToString(rune(args.Rng.Int31()))
+		return runesToString(rune(args.nextChoice(CharClassChoice, math.MaxInt32)))
 	}}, nil
 }
 
-func opAnyCharNotNl(regexp *syntax.Regexp, args *GeneratorArgs) (*internalGenerator, error) {
+func opAnyCharNotNl(regexp *syntax.Regexp, genArgs *GeneratorArgs) (*internalGenerator, error) {
 	enforceOp(regexp, syntax.OpAnyCharNotNL)
+	if genArgs.ByteMode {
+		return &internalGenerator{regexp.String(), func(args *runtimeArgs) string {
+			b := args.nextChoice(CharClassChoice, 255)
+			if b >= '\n' {
+				b++
+			}
+			return bytesToString(byte(b))
+		}}, nil
+	}
 	charClass := newCharClass(1, rune(math.MaxInt32))
-	return createCharClassGenerator(regexp.String(), charClass, args)
+	return createCharClassGenerator(regexp.String(), charClass, genArgs)
 }
 
 func opQuest(regexp *syntax.Regexp, args *GeneratorArgs) (*internalGenerator, error) {
@@ -150,10 +182,18 @@ func opRepeat(regexp *syntax.Regexp, args *GeneratorArgs) (*internalGenerator, e
 
 // Handles syntax.ClassNL because the parser uses that flag to generate character
 // classes that respect it.
-func opCharClass(regexp *syntax.Regexp, args *GeneratorArgs) (*internalGenerator, error) {
+func opCharClass(regexp *syntax.Regexp, genArgs *GeneratorArgs) (*internalGenerator, error) {
 	enforceOp(regexp, syntax.OpCharClass)
+	if genArgs.ByteMode {
+		byteClass := newByteClassFromRunePairs(regexp.Rune)
+		if byteClass.TotalSize == 0 {
+			return nil, generatorError(nil,
+				"character class /%s/ has no bytes in [0,255]; byte mode requires at least one rune range to overlap that range", regexp)
+		}
+		return createByteClassGenerator(regexp.String(), byteClass)
+	}
 	charClass := parseCharClass(regexp.Rune)
-	return createCharClassGenerator(regexp.String(), charClass, args)
+	return createCharClassGenerator(regexp.String(), charClass, genArgs)
 }
 
 func opConcat(regexp *syntax.Regexp, genArgs *GeneratorArgs) (*internalGenerator, error) {
@@ -179,21 +219,40 @@ func opAlternate(regexp *syntax.Regexp, genArgs *GeneratorArgs) (*internalGenera
 
 	var numGens int = len(generators)
 
+	weights := make([]float64, numGens)
+	for i, sub := range regexp.Sub {
+		weights[i] = genArgs.Weights.weightOf(sub, i)
+	}
+
 	return &internalGenerator{regexp.String(), func(runArgs *runtimeArgs) string {
-		i := runArgs.Rng.Intn(numGens)
+		i := pickWeightedIndex(runArgs, weights)
 		generator := generators[i]
 		return generator.Generate(runArgs)
 	}}, nil
 }
 
-func opCapture(regexp *syntax.Regexp, args *GeneratorArgs) (*internalGenerator, error) {
+func opCapture(regexp *syntax.Regexp, genArgs *GeneratorArgs) (*internalGenerator, error) {
 	enforceOp(regexp, syntax.OpCapture)
 
 	if err := enforceSingleSub(regexp); err != nil {
 		return nil, err
 	}
 
-	return newGenerator(regexp.Sub[0], args)
+	subInternal, err := newGenerator(regexp.Sub[0], genArgs)
+	if err != nil {
+		return nil, err
+	}
+
+	if genArgs.CaptureGroupHandler == nil {
+		return subInternal, nil
+	}
+
+	index, name, group := regexp.Cap, regexp.Name, regexp.Sub[0]
+
+	return &internalGenerator{regexp.String(), func(runArgs *runtimeArgs) string {
+		subGenerator := &generator{internalGenerator: subInternal, genArgs: genArgs, rng: runArgs.Rng, sharedRunArgs: runArgs}
+		return genArgs.CaptureGroupHandler(index, name, group, subGenerator, genArgs)
+	}}, nil
 }
 
 // Panic if r.Op != op.
@@ -214,12 +273,22 @@ func enforceSingleSub(regexp *syntax.Regexp) error {
 
 func createCharClassGenerator(name string, charClass *tCharClass, args *GeneratorArgs) (*internalGenerator, error) {
 	return &internalGenerator{name, func(args *runtimeArgs) string {
-		i := args.Rng.Int31n(charClass.TotalSize)
+		i := args.nextChoice(CharClassChoice, charClass.TotalSize)
 		r := charClass.GetRuneAt(i)
 		return runesToString(r)
 	}}, nil
 }
 
+// createByteClassGenerator is the byte-mode counterpart of
+// createCharClassGenerator: it picks uniformly among byteClass's byte
+// ranges instead of charClass's rune ranges.
+func createByteClassGenerator(name string, byteClass *tByteClass) (*internalGenerator, error) {
+	return &internalGenerator{name, func(args *runtimeArgs) string {
+		i := args.nextChoice(CharClassChoice, byteClass.TotalSize)
+		return bytesToString(byteClass.GetByteAt(i))
+	}}, nil
+}
+
 // Returns a generator that will run the generator for r's sub-expression [min, max] times.
 func createRepeatingGenerator(regexp *syntax.Regexp, genArgs *GeneratorArgs, min int, max int) (*internalGenerator, error) {
 	if err := enforceSingleSub(regexp); err != nil {
@@ -232,11 +301,21 @@ func createRepeatingGenerator(regexp *syntax.Regexp, genArgs *GeneratorArgs, min
 	}
 
 	if max < 0 {
-		max = maxUpperBound
+		max = genArgs.maxUnboundedRepeatCount()
+		if floor := genArgs.minUnboundedRepeatCount(); floor > min {
+			min = floor
+		}
+		// Defensive: args.initialize() already rejects a
+		// MinUnboundedRepeatCount greater than MaxUnboundedRepeatCount, but
+		// clamp here too rather than let pickRepeatCount see an inverted
+		// [min, max] and panic.
+		if min > max {
+			min = max
+		}
 	}
 
 	return &internalGenerator{regexp.String(), func(runArgs *runtimeArgs) string {
-		n := min + runArgs.Rng.Intn(max-min+1)
+		n := pickRepeatCount(runArgs, genArgs.RepeatDistribution, min, max)
 		return executeGeneratorRepeatedly(genArgs.Executor, runArgs, generator, n)
 	}}, nil
-}
\ No newline at end of file
+}
@@ -0,0 +1,136 @@
+/*
+Copyright 2014 Zachary Klippenstein
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package rapidgen adapts a regen.Generator into a value generator usable by
+// property-based testing frameworks: in addition to drawing new random
+// values, it can shrink a value that made a property fail toward a smaller
+// one that still fails.
+//
+// Shrinking works on the regen.Trace captured when the value was drawn,
+// rather than on the generated string itself: depending on what kind of
+// choice produced each entry, it binary-searches for smaller repetition
+// counts, jumps straight to the first alternative, or jumps straight to a
+// character class's smallest element, then replays the edited Trace to see
+// what string those smaller choices produce.
+package rapidgen
+
+import (
+	"pgregory.net/rapid"
+
+	"github.com/tg732475/goregen"
+)
+
+// String returns a rapid generator that draws strings matching pattern, for
+// dropping a regen pattern directly into a rapid-style property test (e.g.
+// `rapid.Check(t, func(t *rapid.T) { s := rapidgen.String(pattern).Draw(t,
+// "s"); ... })`). Each draw compiles pattern fresh and regenerates it from a
+// seed rapid itself draws, so rapid's own shrinking of that seed carries
+// over to the string it produced.
+func String(pattern string) *rapid.Generator[string] {
+	return rapid.Custom(func(t *rapid.T) string {
+		gen, err := regen.NewGenerator(pattern, nil)
+		if err != nil {
+			t.Fatalf("rapidgen: invalid pattern /%s/: %v", pattern, err)
+		}
+
+		seed := rapid.Int64().Draw(t, "seed")
+		value, err := gen.GenerateSeeded(seed)
+		if err != nil && err != regen.ErrOutputTruncated {
+			t.Fatalf("rapidgen: generating /%s/: %v", pattern, err)
+		}
+		return value
+	})
+}
+
+// Generator draws values from an underlying regen.Generator for a
+// property-based test, and can shrink a value that failed the test. Unlike
+// String, it works directly off a caller-supplied regen.Generator and its
+// recorded Trace rather than rapid's own shrinking machinery -- useful for
+// frameworks other than rapid, or for driving the shrink loop by hand.
+type Generator struct {
+	gen   regen.Generator
+	trace *regen.Trace
+	value string
+}
+
+// New wraps gen for use as a property-based test generator.
+func New(gen regen.Generator) *Generator {
+	return &Generator{gen: gen}
+}
+
+// Draw produces a new random value and remembers the Trace that produced it,
+// so a later Shrink call has something to shrink.
+func (g *Generator) Draw() string {
+	g.value, g.trace, _ = regen.GenerateTraced(g.gen)
+	return g.value
+}
+
+// Value returns the most recently drawn or shrunk value.
+func (g *Generator) Value() string {
+	return g.value
+}
+
+// shrinkCandidate returns the value a Choice should move toward when
+// shrinking, given its kind: repetition counts binary-search toward 0 one
+// halving at a time, while alternation and character-class picks have no
+// useful ordering to exploit and so jump straight to 0 (the first
+// alternative, or the class's smallest element).
+func shrinkCandidate(choice regen.Choice) int32 {
+	switch choice.Kind {
+	case regen.AlternateChoice, regen.CharClassChoice:
+		return 0
+	default:
+		return choice.Value / 2
+	}
+}
+
+// Shrink tries moving each recorded choice in the current value's Trace
+// toward shrinkCandidate's target for its kind, in turn, keeping any edit
+// for which fails still returns true. It keeps sweeping over the choices
+// until a full sweep makes no further progress, then returns the smallest
+// failing value found (the value itself if none of its variants failed).
+func (g *Generator) Shrink(fails func(value string) bool) string {
+	if g.trace == nil {
+		return g.value
+	}
+
+	for progress := true; progress; {
+		progress = false
+
+		for i, choice := range g.trace.Choices {
+			candidate := shrinkCandidate(choice)
+			if candidate == choice.Value {
+				continue
+			}
+
+			shrunk := append([]regen.Choice(nil), g.trace.Choices...)
+			shrunk[i].Value = candidate
+			candidateTrace := &regen.Trace{Choices: shrunk}
+			value, _ := regen.Replay(g.gen, candidateTrace)
+
+			if !fails(value) {
+				continue
+			}
+
+			g.value = value
+			g.trace = candidateTrace
+			progress = true
+			break
+		}
+	}
+
+	return g.value
+}
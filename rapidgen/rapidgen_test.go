@@ -0,0 +1,43 @@
+/*
+Copyright 2014 Zachary Klippenstein
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rapidgen
+
+import (
+	"testing"
+
+	"github.com/tg732475/goregen"
+)
+
+// shrinkCandidate must pick a strategy based on what produced the choice:
+// alternation and char-class picks jump straight to 0, while repeat counts
+// only halve.
+func TestShrinkCandidateIsKindAware(t *testing.T) {
+	cases := []struct {
+		choice regen.Choice
+		want   int32
+	}{
+		{regen.Choice{Kind: regen.RepeatChoice, Value: 8}, 4},
+		{regen.Choice{Kind: regen.AlternateChoice, Value: 2}, 0},
+		{regen.Choice{Kind: regen.CharClassChoice, Value: 5}, 0},
+	}
+
+	for _, c := range cases {
+		if got := shrinkCandidate(c.choice); got != c.want {
+			t.Errorf("shrinkCandidate(%+v) = %d, want %d", c.choice, got, c.want)
+		}
+	}
+}
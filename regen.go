@@ -0,0 +1,285 @@
+/*
+Copyright 2014 Zachary Klippenstein
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package regen generates random strings that match a given regular
+// expression.
+package regen
+
+import (
+	"math/rand"
+	"regexp/syntax"
+	"time"
+)
+
+// GeneratorArgs bundles the configuration used to build and run a Generator.
+// The zero value is valid and selects the package's defaults.
+type GeneratorArgs struct {
+	// Executor determines how concatenated sub-expressions are combined.
+	// Defaults to the package's built-in concatenating Executor if nil.
+	Executor Executor
+
+	// ByteMode, if true, makes the generator produce arbitrary byte strings
+	// rather than valid UTF-8 text: `.` and char classes are drawn from
+	// [0,255] as raw bytes instead of from the Unicode code space. Intended
+	// for use via NewByteGenerator; most callers of NewGenerator should
+	// leave this false.
+	ByteMode bool
+
+	// RngSource seeds the Generator's random number generator. If nil, a
+	// source seeded from the current time is used, and successive
+	// Generate() calls are not reproducible. Set this (e.g. to
+	// rand.NewSource(seed)) to get a Generator whose output is fully
+	// determined by the pattern, args and source -- useful for fuzz-test
+	// reproducibility or when a peer needs to regenerate an identical
+	// string from the same seed.
+	RngSource rand.Source
+
+	// Weights, if set, makes opAlternate pick among a `|` pattern's
+	// sub-expressions with the given relative probabilities instead of
+	// uniformly.
+	Weights *AlternateWeights
+
+	// RepeatDistribution, if set, makes repetitions (`*`, `+`, `{m,n}`, ...)
+	// draw their count from the given distribution instead of uniformly
+	// across [min, max].
+	RepeatDistribution *RepeatDistribution
+
+	// CaptureGroupHandler, if set, is invoked by opCapture for every capture
+	// group instead of just recursively generating the group's contents.
+	// This lets a caller plug in domain-specific data (e.g. a real name for
+	// `(?P<name>\w+)`) keyed off the group's index or name.
+	CaptureGroupHandler CaptureGroupHandler
+
+	// MinUnboundedRepeatCount, if positive, is used as the effective minimum
+	// repeat count when a repetition's minimum is unbounded. Defaults to 0.
+	MinUnboundedRepeatCount int
+
+	// MaxUnboundedRepeatCount, if positive, is used as the effective maximum
+	// repeat count when a repetition's maximum is unbounded, e.g. the `*` in
+	// `a*`. Defaults to maxUpperBound.
+	MaxUnboundedRepeatCount int
+
+	// MaxOutputLength, if positive, caps the total length of a single
+	// generated string. Once the budget is used up, opConcat and repeating
+	// generators stop appending further pieces rather than continuing to
+	// grow a string that's already at its limit -- useful for keeping `.*`
+	// and similar unbounded patterns from producing huge output.
+	MaxOutputLength int
+}
+
+// CaptureGroupHandler substitutes a caller-supplied value for a capture
+// group. index and name identify the group (name is "" for an unnamed
+// group); group is the group's AST. subGenerator reproduces the group's
+// default (non-handled) behavior and may be called zero or more times, e.g.
+// to draw a few candidates and keep the best one.
+type CaptureGroupHandler func(index int, name string, group *syntax.Regexp, subGenerator Generator, args *GeneratorArgs) string
+
+// initialize fills in defaults for any fields of args left unset. Called
+// once when building a Generator.
+func (args *GeneratorArgs) initialize() error {
+	if args.Executor == nil {
+		args.Executor = concatExecutor{}
+	}
+	if args.RngSource == nil {
+		args.RngSource = rand.NewSource(time.Now().UnixNano())
+	}
+	if args.MinUnboundedRepeatCount > 0 && args.MaxUnboundedRepeatCount > 0 &&
+		args.MinUnboundedRepeatCount > args.MaxUnboundedRepeatCount {
+		return generatorError(nil,
+			"MinUnboundedRepeatCount (%d) is greater than MaxUnboundedRepeatCount (%d)",
+			args.MinUnboundedRepeatCount, args.MaxUnboundedRepeatCount)
+	}
+	return nil
+}
+
+// Generator generates random strings that match a regular expression.
+type Generator interface {
+	// Generate returns a new random string that matches the pattern the
+	// Generator was built from. If GeneratorArgs.MaxOutputLength cut the
+	// string short, it is returned alongside ErrOutputTruncated.
+	Generate() (string, error)
+
+	// GenerateSeeded is like Generate, but draws from a fresh random source
+	// seeded with seed instead of the Generator's own, leaving the
+	// Generator's own random state untouched. Calling it twice with the same
+	// seed on the same Generator always produces the same string.
+	GenerateSeeded(seed int64) (string, error)
+
+	String() string
+}
+
+// generator is the default Generator implementation, returned by
+// NewGenerator and NewGeneratorFromSyntax.
+type generator struct {
+	internalGenerator *internalGenerator
+	genArgs           *GeneratorArgs
+	rng               *rand.Rand
+
+	// sharedRunArgs, if non-nil, is used by Generate in place of building a
+	// fresh runtimeArgs. It's set when this generator is the subGenerator
+	// handed to a CaptureGroupHandler, so the capture's generation threads
+	// through the same budget/Trace/Replay state as the rest of the parent
+	// Generate call instead of starting a disconnected one of its own.
+	sharedRunArgs *runtimeArgs
+}
+
+func (g *generator) Generate() (string, error) {
+	runArgs := g.sharedRunArgs
+	if runArgs == nil {
+		runArgs = g.genArgs.newRuntimeArgs(g.rng)
+	}
+	result, truncated := enforceBudget(g.genArgs, runArgs, g.internalGenerator.Generate(runArgs))
+	if truncated {
+		return result, ErrOutputTruncated
+	}
+	return result, nil
+}
+
+func (g *generator) GenerateSeeded(seed int64) (string, error) {
+	rng := rand.New(rand.NewSource(seed))
+	runArgs := g.genArgs.newRuntimeArgs(rng)
+	result, truncated := enforceBudget(g.genArgs, runArgs, g.internalGenerator.Generate(runArgs))
+	if truncated {
+		return result, ErrOutputTruncated
+	}
+	return result, nil
+}
+
+func (g *generator) String() string {
+	return g.internalGenerator.String()
+}
+
+// NewGenerator parses pattern as a regular expression and returns a
+// Generator that produces random strings matching it. args may be nil to
+// accept all defaults.
+func NewGenerator(pattern string, args *GeneratorArgs) (Generator, error) {
+	re, err := syntax.Parse(pattern, syntax.Perl)
+	if err != nil {
+		return nil, generatorError(err, "parsing /%s/", pattern)
+	}
+
+	return NewGeneratorFromSyntax(re, args)
+}
+
+// NewGeneratorFromSyntax is like NewGenerator, but takes an already-parsed
+// *syntax.Regexp instead of a pattern string. It's for callers that already
+// have an AST on hand -- because they parsed it themselves for analysis or
+// rewriting, or built it programmatically -- and would otherwise have to
+// re-serialize it to a string just to have NewGenerator re-parse it. args
+// may be nil to accept all defaults.
+func NewGeneratorFromSyntax(re *syntax.Regexp, args *GeneratorArgs) (Generator, error) {
+	if re == nil {
+		return nil, generatorError(nil, "NewGeneratorFromSyntax: re is nil")
+	}
+	if args == nil {
+		args = &GeneratorArgs{}
+	}
+	if err := args.initialize(); err != nil {
+		return nil, err
+	}
+
+	internalGen, err := newGenerator(re, args)
+	if err != nil {
+		return nil, err
+	}
+
+	return &generator{
+		internalGenerator: internalGen,
+		genArgs:           args,
+		rng:               rand.New(args.RngSource),
+	}, nil
+}
+
+// ByteGenerator is the byte-mode counterpart of Generator: it produces
+// arbitrary byte strings rather than valid UTF-8 text.
+type ByteGenerator interface {
+	// Generate returns new random bytes that match the pattern the
+	// ByteGenerator was built from. If GeneratorArgs.MaxOutputLength cut
+	// the output short, it is returned alongside ErrOutputTruncated.
+	Generate() ([]byte, error)
+
+	// GenerateSeeded is like Generate, but draws from a fresh random source
+	// seeded with seed instead of the ByteGenerator's own, leaving the
+	// ByteGenerator's own random state untouched.
+	GenerateSeeded(seed int64) ([]byte, error)
+
+	String() string
+}
+
+// byteGenerator is the default ByteGenerator implementation, returned by
+// NewByteGenerator.
+type byteGenerator struct {
+	internalGenerator *internalGenerator
+	genArgs           *GeneratorArgs
+	rng               *rand.Rand
+}
+
+func (g *byteGenerator) Generate() ([]byte, error) {
+	runArgs := g.genArgs.newRuntimeArgs(g.rng)
+	result, truncated := enforceBudget(g.genArgs, runArgs, g.internalGenerator.Generate(runArgs))
+	if truncated {
+		return []byte(result), ErrOutputTruncated
+	}
+	return []byte(result), nil
+}
+
+func (g *byteGenerator) GenerateSeeded(seed int64) ([]byte, error) {
+	rng := rand.New(rand.NewSource(seed))
+	runArgs := g.genArgs.newRuntimeArgs(rng)
+	result, truncated := enforceBudget(g.genArgs, runArgs, g.internalGenerator.Generate(runArgs))
+	if truncated {
+		return []byte(result), ErrOutputTruncated
+	}
+	return []byte(result), nil
+}
+
+func (g *byteGenerator) String() string {
+	return g.internalGenerator.String()
+}
+
+// NewByteGenerator is like NewGenerator, but returns a ByteGenerator that
+// produces arbitrary byte strings: `.` and character classes are drawn from
+// [0,255] as raw bytes rather than from the Unicode code space. args may be
+// nil to accept all defaults; if non-nil, it is copied before ByteMode is
+// forced to true on the copy, leaving the caller's GeneratorArgs untouched.
+func NewByteGenerator(pattern string, args *GeneratorArgs) (ByteGenerator, error) {
+	var byteArgs GeneratorArgs
+	if args != nil {
+		byteArgs = *args
+	}
+	byteArgs.ByteMode = true
+	args = &byteArgs
+
+	if err := args.initialize(); err != nil {
+		return nil, err
+	}
+
+	re, err := syntax.Parse(pattern, syntax.Perl)
+	if err != nil {
+		return nil, generatorError(err, "parsing /%s/", pattern)
+	}
+
+	internalGen, err := newGenerator(re, args)
+	if err != nil {
+		return nil, err
+	}
+
+	return &byteGenerator{
+		internalGenerator: internalGen,
+		genArgs:           args,
+		rng:               rand.New(args.RngSource),
+	}, nil
+}
@@ -0,0 +1,44 @@
+/*
+Copyright 2014 Zachary Klippenstein
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package regen
+
+import "testing"
+
+// GenerateSeeded must return the same string every time it's called with
+// the same seed, and leave the Generator's own random state untouched so
+// later unseeded Generate calls aren't affected.
+func TestGenerateSeededIsDeterministic(t *testing.T) {
+	gen, err := NewGenerator(`[a-z]{10}`, nil)
+	if err != nil {
+		t.Fatalf("NewGenerator: %v", err)
+	}
+
+	first, err := gen.GenerateSeeded(42)
+	if err != nil {
+		t.Fatalf("GenerateSeeded: %v", err)
+	}
+
+	for i := 0; i < 10; i++ {
+		again, err := gen.GenerateSeeded(42)
+		if err != nil {
+			t.Fatalf("GenerateSeeded: %v", err)
+		}
+		if again != first {
+			t.Fatalf("GenerateSeeded(42) = %q on call %d, want %q (same as the first call)", again, i, first)
+		}
+	}
+}
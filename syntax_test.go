@@ -0,0 +1,54 @@
+/*
+Copyright 2014 Zachary Klippenstein
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package regen
+
+import (
+	"regexp"
+	"regexp/syntax"
+	"testing"
+)
+
+// NewGeneratorFromSyntax must build a working Generator from an
+// already-parsed AST, matching what NewGenerator would build from the
+// equivalent pattern string.
+func TestNewGeneratorFromSyntaxGeneratesMatchingOutput(t *testing.T) {
+	pattern := `[a-c]{5}`
+	re, err := syntax.Parse(pattern, syntax.Perl)
+	if err != nil {
+		t.Fatalf("syntax.Parse: %v", err)
+	}
+
+	gen, err := NewGeneratorFromSyntax(re, nil)
+	if err != nil {
+		t.Fatalf("NewGeneratorFromSyntax: %v", err)
+	}
+
+	value, err := gen.Generate()
+	if err != nil {
+		t.Fatalf("Generate: %v", err)
+	}
+
+	if !regexp.MustCompile("^"+pattern+"$").MatchString(value) {
+		t.Fatalf("got %q, want a match for /%s/", value, pattern)
+	}
+}
+
+func TestNewGeneratorFromSyntaxRejectsNil(t *testing.T) {
+	if _, err := NewGeneratorFromSyntax(nil, nil); err == nil {
+		t.Fatal("got nil error, want an error for a nil *syntax.Regexp")
+	}
+}
@@ -0,0 +1,114 @@
+/*
+Copyright 2014 Zachary Klippenstein
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package regen
+
+// ChoiceKind identifies which kind of random decision a recorded Choice came
+// from, so a shrinker can apply a strategy suited to that decision -- e.g.
+// binary-searching a repetition count toward 0, but jumping an alternation
+// straight to its first branch -- instead of treating every choice the same
+// way.
+type ChoiceKind int
+
+const (
+	// RepeatChoice is an offset from a repetition's minimum count (`*`,
+	// `+`, `{m,n}`). Smaller offsets produce fewer repetitions, so this
+	// shrinks well by binary search toward 0.
+	RepeatChoice ChoiceKind = iota
+	// AlternateChoice is the index of the branch taken in a `|` pattern.
+	// There's no ordering to exploit, so this shrinks by jumping straight
+	// to branch 0.
+	AlternateChoice
+	// CharClassChoice is the index of the rune or byte picked within a
+	// character class, counting ranges low to high. This shrinks by
+	// jumping straight to index 0, the class's smallest element.
+	CharClassChoice
+)
+
+// Choice is one recorded random decision: Value is the raw choice (an index
+// or offset, depending on Kind; see the ChoiceKind docs), and Kind says how a
+// shrinker should interpret it.
+type Choice struct {
+	Kind  ChoiceKind
+	Value int32
+}
+
+// Trace records the sequence of random choices (repetition counts,
+// alternation branches, character-class picks) made while generating a
+// single string. It can be fed back in as a Replay to deterministically
+// reproduce that string, or edited before replaying to explore "smaller"
+// nearby generations -- the basis for the shrinking done by regen/rapidgen.
+type Trace struct {
+	Choices []Choice
+}
+
+// nextChoice returns the next choice of the given kind in [0, n): replayed
+// from args.Replay if one is still available, otherwise drawn fresh from
+// args.Rng. Either way, the choice actually used is appended to args.Trace
+// if tracing is enabled.
+func (args *runtimeArgs) nextChoice(kind ChoiceKind, n int32) int32 {
+	var c int32
+	if args.Replay != nil && args.replayIndex < len(args.Replay.Choices) {
+		c = args.Replay.Choices[args.replayIndex].Value
+		args.replayIndex++
+	} else {
+		c = args.Rng.Int31n(n)
+	}
+	args.record(kind, c)
+	return c
+}
+
+// record appends a Choice of the given kind and value to args.Trace if
+// tracing is enabled.
+func (args *runtimeArgs) record(kind ChoiceKind, c int32) {
+	if args.Trace != nil {
+		args.Trace.Choices = append(args.Trace.Choices, Choice{Kind: kind, Value: c})
+	}
+}
+
+// GenerateTraced runs gen and returns both the string it produced and the
+// Trace of random choices that produced it. gen must have been returned by
+// this package (NewGenerator or NewGeneratorFromSyntax); passing any other
+// Generator implementation panics. If GeneratorArgs.MaxOutputLength cut the
+// string short, it is returned alongside ErrOutputTruncated.
+func GenerateTraced(gen Generator) (string, *Trace, error) {
+	g := gen.(*generator)
+	trace := &Trace{}
+	runArgs := g.genArgs.newRuntimeArgs(g.rng)
+	runArgs.Trace = trace
+	result, truncated := enforceBudget(g.genArgs, runArgs, g.internalGenerator.Generate(runArgs))
+	if truncated {
+		return result, trace, ErrOutputTruncated
+	}
+	return result, trace, nil
+}
+
+// Replay regenerates the string gen would have produced for trace,
+// substituting trace's recorded choices for fresh randomness wherever one is
+// available. It's how a shrinker checks whether an edited Trace still
+// reproduces a failure, without needing a fresh seed. If
+// GeneratorArgs.MaxOutputLength cut the string short, it is returned
+// alongside ErrOutputTruncated.
+func Replay(gen Generator, trace *Trace) (string, error) {
+	g := gen.(*generator)
+	runArgs := g.genArgs.newRuntimeArgs(g.rng)
+	runArgs.Replay = trace
+	result, truncated := enforceBudget(g.genArgs, runArgs, g.internalGenerator.Generate(runArgs))
+	if truncated {
+		return result, ErrOutputTruncated
+	}
+	return result, nil
+}
@@ -0,0 +1,40 @@
+/*
+Copyright 2014 Zachary Klippenstein
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package regen
+
+import "testing"
+
+// `.` must draw through nextChoice like every other random decision, so
+// GenerateTraced + Replay reproduce the same string instead of desyncing
+// whenever a `.` draw goes unrecorded.
+func TestAnyCharTraceReplay(t *testing.T) {
+	gen, err := NewGenerator(`[ab]{3}(?s).`, nil)
+	if err != nil {
+		t.Fatalf("NewGenerator: %v", err)
+	}
+
+	for i := 0; i < 5000; i++ {
+		value, trace, _ := GenerateTraced(gen)
+		replayed, err := Replay(gen, trace)
+		if err != nil {
+			t.Fatalf("Replay: %v", err)
+		}
+		if replayed != value {
+			t.Fatalf("Replay produced %q, want %q (original)", replayed, value)
+		}
+	}
+}
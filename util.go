@@ -0,0 +1,59 @@
+/*
+Copyright 2014 Zachary Klippenstein
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package regen
+
+import (
+	"fmt"
+	"regexp/syntax"
+)
+
+// maxUpperBound is the repeat count substituted for an unbounded repetition
+// (e.g. the `*` in `a*`) when the caller hasn't configured a tighter bound.
+const maxUpperBound = 100
+
+// runesToString converts a sequence of runes into the string they encode.
+func runesToString(runes ...rune) string {
+	return string(runes)
+}
+
+// bytesToString builds a string directly out of raw bytes, bypassing UTF-8
+// encoding. Used by the byte-mode generators so that values in [128,255]
+// come out as single bytes rather than two-byte UTF-8 sequences.
+func bytesToString(bs ...byte) string {
+	return string(bs)
+}
+
+// generatorError wraps cause (which may be nil) in a message built from
+// format and args.
+func generatorError(cause error, format string, args ...interface{}) error {
+	msg := fmt.Sprintf(format, args...)
+	if cause != nil {
+		return fmt.Errorf("%s: %s", msg, cause)
+	}
+	return fmt.Errorf("%s", msg)
+}
+
+// opToString returns a human-readable name for a syntax.Op, for use in error
+// messages and generator names.
+func opToString(op syntax.Op) string {
+	return op.String()
+}
+
+// inspectRegexpToString returns a debug representation of re's parsed AST.
+func inspectRegexpToString(re *syntax.Regexp) string {
+	return fmt.Sprintf("%#v", re)
+}
@@ -0,0 +1,201 @@
+/*
+Copyright 2014 Zachary Klippenstein
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package regen
+
+import (
+	"math"
+	"math/rand"
+	"regexp/syntax"
+)
+
+// AlternateWeights customizes how opAlternate picks among a `|` pattern's
+// sub-expressions. For a given alternation, the weight of its i'th
+// sub-pattern is taken from ByIndex if present there, else from Weight if
+// set, else defaults to 1 -- i.e. uniform unless configured otherwise.
+type AlternateWeights struct {
+	// ByIndex maps a sub-pattern's position within its alternation to a
+	// relative weight.
+	ByIndex map[int]float64
+
+	// Weight, if set, is called for each sub-pattern of every alternation
+	// with its AST and position, and returns its relative weight.
+	Weight func(regex *syntax.Regexp, alternateIndex int) float64
+}
+
+func (w *AlternateWeights) weightOf(regex *syntax.Regexp, alternateIndex int) float64 {
+	if w == nil {
+		return 1
+	}
+	if weight, ok := w.ByIndex[alternateIndex]; ok {
+		return weight
+	}
+	if w.Weight != nil {
+		return w.Weight(regex, alternateIndex)
+	}
+	return 1
+}
+
+// pickWeightedIndex chooses one of len(weights) indices with probability
+// proportional to weights, recording or replaying the choice as an
+// AlternateChoice like runtimeArgs.nextChoice does.
+func pickWeightedIndex(args *runtimeArgs, weights []float64) int {
+	if args.Replay != nil && args.replayIndex < len(args.Replay.Choices) {
+		i := args.Replay.Choices[args.replayIndex].Value
+		args.replayIndex++
+		args.record(AlternateChoice, i)
+		return int(i)
+	}
+
+	total := 0.0
+	for _, weight := range weights {
+		total += weight
+	}
+
+	r := args.Rng.Float64() * total
+	i := 0
+	for ; i < len(weights)-1; i++ {
+		if r < weights[i] {
+			break
+		}
+		r -= weights[i]
+	}
+
+	args.record(AlternateChoice, int32(i))
+	return i
+}
+
+// RepeatDistributionKind selects how createRepeatingGenerator draws a
+// repetition count for `*`, `+`, `?` and `{m,n}`.
+type RepeatDistributionKind int
+
+const (
+	// UniformRepeat picks uniformly across [min, max]. This is the default.
+	UniformRepeat RepeatDistributionKind = iota
+	// GeometricRepeat picks an offset from min via a geometric distribution
+	// with success probability RepeatDistribution.P, clamped to max.
+	GeometricRepeat
+	// PoissonRepeat picks an offset from min via a Poisson distribution with
+	// rate RepeatDistribution.Lambda, clamped to max.
+	PoissonRepeat
+	// CustomRepeat delegates entirely to RepeatDistribution.Custom.
+	CustomRepeat
+)
+
+// RepeatDistribution configures the distribution createRepeatingGenerator
+// draws repetition counts from.
+type RepeatDistribution struct {
+	Kind RepeatDistributionKind
+
+	// P is the success probability used when Kind is GeometricRepeat.
+	P float64
+
+	// Lambda is the rate parameter used when Kind is PoissonRepeat.
+	Lambda float64
+
+	// Custom, used when Kind is CustomRepeat, returns the repetition count
+	// directly; it's given min and max for context and should return a
+	// value in [min, max].
+	Custom func(min, max int, rng *rand.Rand) int
+}
+
+// pickRepeatCount draws a repetition count in [min, max] according to dist
+// (or uniformly if dist is nil), recording or replaying the choice as a
+// RepeatChoice (an offset from min) like runtimeArgs.nextChoice does.
+func pickRepeatCount(args *runtimeArgs, dist *RepeatDistribution, min, max int) int {
+	span := int32(max - min)
+
+	if args.Replay != nil && args.replayIndex < len(args.Replay.Choices) {
+		offset := args.Replay.Choices[args.replayIndex].Value
+		args.replayIndex++
+		args.record(RepeatChoice, offset)
+		return min + int(offset)
+	}
+
+	if dist == nil || dist.Kind == UniformRepeat {
+		offset := args.Rng.Int31n(span + 1)
+		args.record(RepeatChoice, offset)
+		return min + int(offset)
+	}
+
+	if dist.Kind == CustomRepeat {
+		n := clampInt(dist.Custom(min, max, args.Rng), min, max)
+		args.record(RepeatChoice, int32(n-min))
+		return n
+	}
+
+	var offset int
+	switch dist.Kind {
+	case GeometricRepeat:
+		offset = geometricSample(args.Rng, dist.P, int(span))
+	case PoissonRepeat:
+		offset = poissonSample(args.Rng, dist.Lambda, int(span))
+	default:
+		offset = int(args.Rng.Int31n(span + 1))
+	}
+
+	args.record(RepeatChoice, int32(offset))
+	return min + offset
+}
+
+// geometricSample draws a sample from a geometric distribution with success
+// probability p, clamped to [0, span].
+func geometricSample(rng *rand.Rand, p float64, span int) int {
+	if p <= 0 || p > 1 {
+		p = 0.5
+	}
+
+	n := 0
+	for n < span && rng.Float64() >= p {
+		n++
+	}
+	return n
+}
+
+// poissonSample draws a sample from a Poisson distribution with rate lambda,
+// clamped to [0, span], using Knuth's algorithm.
+func poissonSample(rng *rand.Rand, lambda float64, span int) int {
+	if lambda <= 0 {
+		lambda = 1
+	}
+
+	l := math.Exp(-lambda)
+	k := 0
+	p := 1.0
+	for {
+		k++
+		p *= rng.Float64()
+		if p <= l {
+			break
+		}
+	}
+
+	n := k - 1
+	if n > span {
+		n = span
+	}
+	return n
+}
+
+func clampInt(v, lo, hi int) int {
+	if v < lo {
+		return lo
+	}
+	if v > hi {
+		return hi
+	}
+	return v
+}
@@ -0,0 +1,70 @@
+/*
+Copyright 2014 Zachary Klippenstein
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package regen
+
+import (
+	"math/rand"
+	"testing"
+)
+
+// A heavily weighted alternative should dominate the output, not appear
+// with roughly the same frequency as its unweighted sibling.
+func TestAlternateWeightsByIndexBiasesChoice(t *testing.T) {
+	gen, err := NewGenerator(`cat|dog`, &GeneratorArgs{
+		Weights: &AlternateWeights{ByIndex: map[int]float64{0: 99, 1: 1}},
+	})
+	if err != nil {
+		t.Fatalf("NewGenerator: %v", err)
+	}
+
+	var catCount int
+	const trials = 200
+	for i := 0; i < trials; i++ {
+		value, _ := gen.Generate()
+		if value == "cat" {
+			catCount++
+		}
+	}
+
+	if catCount < trials*9/10 {
+		t.Fatalf("got %q chosen %d/%d times, want at least 90%% given its 99:1 weighting", "cat", catCount, trials)
+	}
+}
+
+// RepeatDistribution{Kind: CustomRepeat} must delegate entirely to Custom
+// instead of falling back to a uniform draw.
+func TestCustomRepeatDistributionUsesCustomFunc(t *testing.T) {
+	gen, err := NewGenerator(`a{0,10}`, &GeneratorArgs{
+		RepeatDistribution: &RepeatDistribution{
+			Kind: CustomRepeat,
+			Custom: func(min, max int, rng *rand.Rand) int {
+				return max
+			},
+		},
+	})
+	if err != nil {
+		t.Fatalf("NewGenerator: %v", err)
+	}
+
+	value, err := gen.Generate()
+	if err != nil {
+		t.Fatalf("Generate: %v", err)
+	}
+	if len(value) != 10 {
+		t.Fatalf("got %q (%d bytes), want exactly 10 bytes from Custom always returning max", value, len(value))
+	}
+}